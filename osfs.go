@@ -0,0 +1,42 @@
+package vfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// osFS is an FS that forwards all calls to the real filesystem via the os
+// and io/ioutil packages.
+type osFS struct{}
+
+// OSFS is an FS that forwards all calls to the real filesystem.
+var OSFS FS = osFS{}
+
+func (osFS) Chmod(name string, mode os.FileMode) error                { return os.Chmod(name, mode) }
+func (osFS) Chown(name string, uid, gid int) error                    { return os.Chown(name, uid, gid) }
+func (osFS) Chtimes(name string, atime, mtime time.Time) error        { return os.Chtimes(name, atime, mtime) }
+func (osFS) Create(name string) (*os.File, error)                     { return os.Create(name) }
+func (osFS) Glob(pattern string) ([]string, error)                    { return filepath.Glob(pattern) }
+func (osFS) Link(oldname, newname string) error                       { return os.Link(oldname, newname) }
+func (osFS) Lstat(name string) (os.FileInfo, error)                   { return os.Lstat(name) }
+func (osFS) Mkdir(name string, perm os.FileMode) error                { return os.Mkdir(name, perm) }
+func (osFS) Open(name string) (*os.File, error)                       { return os.Open(name) }
+func (osFS) PathSeparator() rune                                      { return os.PathSeparator }
+func (osFS) ReadDir(dirname string) ([]os.FileInfo, error)            { return ioutil.ReadDir(dirname) }
+func (osFS) ReadFile(filename string) ([]byte, error)                 { return ioutil.ReadFile(filename) }
+func (osFS) Readlink(name string) (string, error)                     { return os.Readlink(name) }
+func (osFS) Remove(name string) error                                 { return os.Remove(name) }
+func (osFS) RemoveAll(name string) error                              { return os.RemoveAll(name) }
+func (osFS) Rename(oldpath, newpath string) error                     { return os.Rename(oldpath, newpath) }
+func (osFS) Stat(name string) (os.FileInfo, error)                    { return os.Stat(name) }
+func (osFS) Symlink(oldname, newname string) error                    { return os.Symlink(oldname, newname) }
+
+func (osFS) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(name, flag, perm)
+}
+
+func (osFS) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	return ioutil.WriteFile(filename, data, perm)
+}
@@ -0,0 +1,34 @@
+// Package vfs provides an abstraction of the filesystem that can be
+// implemented by the real filesystem or by a fake filesystem for testing.
+package vfs
+
+import (
+	"os"
+	"time"
+)
+
+// An FS is an abstraction over a filesystem, loosely modeled on the
+// subset of os's filesystem functions that operate on paths rather than
+// open *os.Files.
+type FS interface {
+	Chmod(name string, mode os.FileMode) error
+	Chown(name string, uid, gid int) error
+	Chtimes(name string, atime, mtime time.Time) error
+	Create(name string) (*os.File, error)
+	Glob(pattern string) ([]string, error)
+	Link(oldname, newname string) error
+	Lstat(name string) (os.FileInfo, error)
+	Mkdir(name string, perm os.FileMode) error
+	Open(name string) (*os.File, error)
+	OpenFile(name string, flag int, perm os.FileMode) (*os.File, error)
+	PathSeparator() rune
+	ReadDir(dirname string) ([]os.FileInfo, error)
+	ReadFile(filename string) ([]byte, error)
+	Readlink(name string) (string, error)
+	Remove(name string) error
+	RemoveAll(name string) error
+	Rename(oldpath, newpath string) error
+	Stat(name string) (os.FileInfo, error)
+	Symlink(oldname, newname string) error
+	WriteFile(filename string, data []byte, perm os.FileMode) error
+}
@@ -0,0 +1,232 @@
+package vfsoverlay
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/twpayne/go-vfs"
+)
+
+// A cacheOnReadFS is a vfs.FS that serves reads from cache when a fresh
+// copy is available, populating cache from base on a miss or once ttl has
+// elapsed, and otherwise behaves as base. Writes go directly to base and
+// invalidate any cached copy.
+type cacheOnReadFS struct {
+	base  vfs.FS
+	cache vfs.FS
+	ttl   time.Duration
+	mu    sync.Mutex
+	at    map[string]time.Time
+}
+
+// NewCacheOnReadFS returns a vfs.FS that reads through cache, populating it
+// from base and re-fetching once a cached entry is older than ttl. A ttl of
+// zero means cached entries never expire.
+func NewCacheOnReadFS(base, cache vfs.FS, ttl time.Duration) vfs.FS {
+	return &cacheOnReadFS{
+		base:  base,
+		cache: cache,
+		ttl:   ttl,
+		at:    make(map[string]time.Time),
+	}
+}
+
+func (c *cacheOnReadFS) fresh(name string) bool {
+	c.mu.Lock()
+	cachedAt, ok := c.at[name]
+	c.mu.Unlock()
+	if !ok {
+		return false
+	}
+	return c.ttl == 0 || time.Since(cachedAt) < c.ttl
+}
+
+func (c *cacheOnReadFS) invalidate(name string) {
+	c.mu.Lock()
+	delete(c.at, name)
+	c.mu.Unlock()
+}
+
+// refresh ensures that name is present and fresh in cache, copying it from
+// base if it is missing or stale. Symlinks are recreated in cache each time
+// they are found stale, directories are created in cache only if missing,
+// and regular file contents are copied.
+func (c *cacheOnReadFS) refresh(name string) error {
+	if c.fresh(name) {
+		return nil
+	}
+	info, err := c.base.Lstat(name)
+	if err != nil {
+		return err
+	}
+	if err := c.copyDirsUp(filepath.Dir(name)); err != nil {
+		return err
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := c.base.Readlink(name)
+		if err != nil {
+			return err
+		}
+		if _, err := c.cache.Lstat(name); err == nil {
+			if err := c.cache.Remove(name); err != nil {
+				return err
+			}
+		}
+		if err := c.cache.Symlink(target, name); err != nil {
+			return err
+		}
+	case info.IsDir():
+		if _, err := c.cache.Lstat(name); os.IsNotExist(err) {
+			if err := c.cache.Mkdir(name, info.Mode().Perm()); err != nil {
+				return err
+			}
+		}
+	default:
+		contents, err := c.base.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		if err := c.cache.WriteFile(name, contents, info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	c.mu.Lock()
+	c.at[name] = time.Now()
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *cacheOnReadFS) copyDirsUp(dir string) error {
+	if dir == "/" || dir == "." || dir == "" {
+		return nil
+	}
+	if _, err := c.cache.Lstat(dir); err == nil {
+		return nil
+	}
+	if err := c.copyDirsUp(filepath.Dir(dir)); err != nil {
+		return err
+	}
+	perm := os.FileMode(0755)
+	if info, err := c.base.Lstat(dir); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := c.cache.Mkdir(dir, perm); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (c *cacheOnReadFS) Chmod(name string, mode os.FileMode) error {
+	c.invalidate(name)
+	return c.base.Chmod(name, mode)
+}
+
+func (c *cacheOnReadFS) Chown(name string, uid, gid int) error {
+	c.invalidate(name)
+	return c.base.Chown(name, uid, gid)
+}
+
+func (c *cacheOnReadFS) Chtimes(name string, atime, mtime time.Time) error {
+	c.invalidate(name)
+	return c.base.Chtimes(name, atime, mtime)
+}
+
+func (c *cacheOnReadFS) Create(name string) (*os.File, error) {
+	c.invalidate(name)
+	return c.base.Create(name)
+}
+
+func (c *cacheOnReadFS) Glob(pattern string) ([]string, error) { return c.base.Glob(pattern) }
+
+func (c *cacheOnReadFS) Link(oldname, newname string) error {
+	c.invalidate(newname)
+	return c.base.Link(oldname, newname)
+}
+
+func (c *cacheOnReadFS) Lstat(name string) (os.FileInfo, error) {
+	if err := c.refresh(name); err != nil {
+		return c.base.Lstat(name)
+	}
+	return c.cache.Lstat(name)
+}
+
+func (c *cacheOnReadFS) Mkdir(name string, perm os.FileMode) error {
+	c.invalidate(name)
+	return c.base.Mkdir(name, perm)
+}
+
+func (c *cacheOnReadFS) Open(name string) (*os.File, error) {
+	if err := c.refresh(name); err != nil {
+		return c.base.Open(name)
+	}
+	return c.cache.Open(name)
+}
+
+func (c *cacheOnReadFS) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE) != 0 {
+		c.invalidate(name)
+		return c.base.OpenFile(name, flag, perm)
+	}
+	if err := c.refresh(name); err != nil {
+		return c.base.OpenFile(name, flag, perm)
+	}
+	return c.cache.OpenFile(name, flag, perm)
+}
+
+func (c *cacheOnReadFS) PathSeparator() rune { return c.base.PathSeparator() }
+
+func (c *cacheOnReadFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	return c.base.ReadDir(dirname)
+}
+
+func (c *cacheOnReadFS) ReadFile(filename string) ([]byte, error) {
+	if err := c.refresh(filename); err != nil {
+		return c.base.ReadFile(filename)
+	}
+	return c.cache.ReadFile(filename)
+}
+
+func (c *cacheOnReadFS) Readlink(name string) (string, error) {
+	if err := c.refresh(name); err != nil {
+		return c.base.Readlink(name)
+	}
+	return c.cache.Readlink(name)
+}
+
+func (c *cacheOnReadFS) Remove(name string) error {
+	c.invalidate(name)
+	return c.base.Remove(name)
+}
+
+func (c *cacheOnReadFS) RemoveAll(name string) error {
+	c.invalidate(name)
+	return c.base.RemoveAll(name)
+}
+
+func (c *cacheOnReadFS) Rename(oldpath, newpath string) error {
+	c.invalidate(oldpath)
+	c.invalidate(newpath)
+	return c.base.Rename(oldpath, newpath)
+}
+
+func (c *cacheOnReadFS) Stat(name string) (os.FileInfo, error) {
+	if err := c.refresh(name); err != nil {
+		return c.base.Stat(name)
+	}
+	return c.cache.Stat(name)
+}
+
+func (c *cacheOnReadFS) Symlink(oldname, newname string) error {
+	c.invalidate(newname)
+	return c.base.Symlink(oldname, newname)
+}
+
+func (c *cacheOnReadFS) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	c.invalidate(filename)
+	return c.base.WriteFile(filename, data, perm)
+}
+
+var _ vfs.FS = &cacheOnReadFS{}
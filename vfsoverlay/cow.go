@@ -0,0 +1,391 @@
+// Package vfsoverlay provides vfs.FS implementations that layer one
+// filesystem on top of another, modeled on afero's copyOnWriteFs and
+// cacheOnReadFs.
+package vfsoverlay
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/twpayne/go-vfs"
+)
+
+// A copyOnWriteFS is a vfs.FS that serves reads from overlay, falling
+// through to base when a path is absent from overlay, and directs all
+// writes to overlay. Deletions are recorded as whiteouts so that entries
+// that still exist in base appear deleted.
+type copyOnWriteFS struct {
+	base    vfs.FS
+	overlay vfs.FS
+	mu      sync.RWMutex
+	deleted map[string]struct{}
+}
+
+// NewCopyOnWriteFS returns a vfs.FS that serves reads from base or
+// overlay, preferring overlay, and directs all writes to overlay, leaving
+// base untouched.
+func NewCopyOnWriteFS(base, overlay vfs.FS) vfs.FS {
+	return &copyOnWriteFS{
+		base:    base,
+		overlay: overlay,
+		deleted: make(map[string]struct{}),
+	}
+}
+
+// Layer reports which layer currently serves path through fs: "overlay" or
+// "base". It returns an error if fs was not returned by NewCopyOnWriteFS or
+// if path does not exist in either layer.
+func Layer(fs vfs.FS, path string) (string, error) {
+	cow, ok := fs.(*copyOnWriteFS)
+	if !ok {
+		return "", fmt.Errorf("vfsoverlay: fs is not a CopyOnWriteFS")
+	}
+	return cow.layer(path)
+}
+
+func (c *copyOnWriteFS) layer(path string) (string, error) {
+	c.mu.RLock()
+	_, deleted := c.deleted[path]
+	c.mu.RUnlock()
+	if deleted {
+		return "", os.ErrNotExist
+	}
+	if _, err := c.overlay.Lstat(path); err == nil {
+		return "overlay", nil
+	}
+	if _, err := c.base.Lstat(path); err == nil {
+		return "base", nil
+	}
+	return "", os.ErrNotExist
+}
+
+// isDeleted reports whether path has been whited out.
+func (c *copyOnWriteFS) isDeleted(path string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.deleted[path]
+	return ok
+}
+
+func (c *copyOnWriteFS) undelete(path string) {
+	c.mu.Lock()
+	delete(c.deleted, path)
+	c.mu.Unlock()
+}
+
+func (c *copyOnWriteFS) whiteout(path string) {
+	c.mu.Lock()
+	c.deleted[path] = struct{}{}
+	c.mu.Unlock()
+}
+
+// copyDirsUp ensures that dir and all of its ancestors exist in the
+// overlay, creating them (with base's permissions, where base has the
+// directory) as needed.
+func (c *copyOnWriteFS) copyDirsUp(dir string) error {
+	if dir == "/" || dir == "." || dir == "" {
+		return nil
+	}
+	if _, err := c.overlay.Lstat(dir); err == nil {
+		return nil
+	}
+	if err := c.copyDirsUp(filepath.Dir(dir)); err != nil {
+		return err
+	}
+	perm := os.FileMode(0755)
+	if info, err := c.base.Lstat(dir); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := c.overlay.Mkdir(dir, perm); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (c *copyOnWriteFS) readPath(path string) (vfs.FS, error) {
+	if c.isDeleted(path) {
+		return nil, os.ErrNotExist
+	}
+	if _, err := c.overlay.Lstat(path); err == nil {
+		return c.overlay, nil
+	}
+	return c.base, nil
+}
+
+func (c *copyOnWriteFS) Chmod(name string, mode os.FileMode) error {
+	if err := c.copyDirsUp(filepath.Dir(name)); err != nil {
+		return err
+	}
+	if _, err := c.overlay.Lstat(name); os.IsNotExist(err) {
+		if err := c.copyUp(name); err != nil {
+			return err
+		}
+	}
+	return c.overlay.Chmod(name, mode)
+}
+
+func (c *copyOnWriteFS) Chown(name string, uid, gid int) error {
+	if err := c.copyDirsUp(filepath.Dir(name)); err != nil {
+		return err
+	}
+	if _, err := c.overlay.Lstat(name); os.IsNotExist(err) {
+		if err := c.copyUp(name); err != nil {
+			return err
+		}
+	}
+	return c.overlay.Chown(name, uid, gid)
+}
+
+func (c *copyOnWriteFS) Chtimes(name string, atime, mtime time.Time) error {
+	if err := c.copyDirsUp(filepath.Dir(name)); err != nil {
+		return err
+	}
+	if _, err := c.overlay.Lstat(name); os.IsNotExist(err) {
+		if err := c.copyUp(name); err != nil {
+			return err
+		}
+	}
+	return c.overlay.Chtimes(name, atime, mtime)
+}
+
+// copyUp copies name from base into overlay, if it exists in base and not
+// already in overlay.
+func (c *copyOnWriteFS) copyUp(name string) error {
+	if _, err := c.overlay.Lstat(name); err == nil {
+		return nil
+	}
+	info, err := c.base.Lstat(name)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return c.overlay.Mkdir(name, info.Mode().Perm())
+	}
+	contents, err := c.base.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	return c.overlay.WriteFile(name, contents, info.Mode().Perm())
+}
+
+func (c *copyOnWriteFS) Create(name string) (*os.File, error) {
+	if err := c.copyDirsUp(filepath.Dir(name)); err != nil {
+		return nil, err
+	}
+	c.undelete(name)
+	return c.overlay.Create(name)
+}
+
+func (c *copyOnWriteFS) Glob(pattern string) ([]string, error) {
+	overlayMatches, err := c.overlay.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	baseMatches, err := c.base.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]struct{}, len(overlayMatches))
+	matches := make([]string, 0, len(overlayMatches)+len(baseMatches))
+	for _, match := range overlayMatches {
+		seen[match] = struct{}{}
+		matches = append(matches, match)
+	}
+	for _, match := range baseMatches {
+		if _, ok := seen[match]; ok || c.isDeleted(match) {
+			continue
+		}
+		matches = append(matches, match)
+	}
+	return matches, nil
+}
+
+func (c *copyOnWriteFS) Link(oldname, newname string) error {
+	if err := c.copyDirsUp(filepath.Dir(newname)); err != nil {
+		return err
+	}
+	if err := c.copyUp(oldname); err != nil {
+		return err
+	}
+	c.undelete(newname)
+	return c.overlay.Link(oldname, newname)
+}
+
+func (c *copyOnWriteFS) Lstat(name string) (os.FileInfo, error) {
+	fs, err := c.readPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Lstat(name)
+}
+
+func (c *copyOnWriteFS) Mkdir(name string, perm os.FileMode) error {
+	if err := c.copyDirsUp(filepath.Dir(name)); err != nil {
+		return err
+	}
+	c.undelete(name)
+	return c.overlay.Mkdir(name, perm)
+}
+
+func (c *copyOnWriteFS) Open(name string) (*os.File, error) {
+	fs, err := c.readPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Open(name)
+}
+
+func (c *copyOnWriteFS) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE) != 0 {
+		if err := c.copyDirsUp(filepath.Dir(name)); err != nil {
+			return nil, err
+		}
+		if flag&os.O_TRUNC == 0 {
+			if err := c.copyUp(name); err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+		}
+		c.undelete(name)
+		return c.overlay.OpenFile(name, flag, perm)
+	}
+	fs, err := c.readPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.OpenFile(name, flag, perm)
+}
+
+func (c *copyOnWriteFS) PathSeparator() rune { return c.base.PathSeparator() }
+
+// ReadDir merges overlay's and base's listings of dirname, preferring
+// overlay's entry when both have one and excluding any children that have
+// been whited out. This mirrors Glob, which merges matches across both
+// layers rather than picking one layer for the whole call.
+func (c *copyOnWriteFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	overlayInfos, overlayErr := c.overlay.ReadDir(dirname)
+	if overlayErr != nil && !os.IsNotExist(overlayErr) {
+		return nil, overlayErr
+	}
+	baseInfos, baseErr := c.base.ReadDir(dirname)
+	if baseErr != nil && !os.IsNotExist(baseErr) {
+		return nil, baseErr
+	}
+	if overlayErr != nil && baseErr != nil {
+		return nil, overlayErr
+	}
+	seen := make(map[string]struct{}, len(overlayInfos))
+	infos := make([]os.FileInfo, 0, len(overlayInfos)+len(baseInfos))
+	for _, info := range overlayInfos {
+		seen[info.Name()] = struct{}{}
+		if !c.isDeleted(join(dirname, info.Name())) {
+			infos = append(infos, info)
+		}
+	}
+	for _, info := range baseInfos {
+		if _, ok := seen[info.Name()]; ok {
+			continue
+		}
+		if c.isDeleted(join(dirname, info.Name())) {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// join joins dir and name into a path, as the overlay filesystem's path
+// convention requires (dir is always "/"-rooted).
+func join(dir, name string) string {
+	if dir == "/" {
+		return "/" + name
+	}
+	return dir + "/" + name
+}
+
+func (c *copyOnWriteFS) ReadFile(filename string) ([]byte, error) {
+	fs, err := c.readPath(filename)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadFile(filename)
+}
+
+func (c *copyOnWriteFS) Readlink(name string) (string, error) {
+	fs, err := c.readPath(name)
+	if err != nil {
+		return "", err
+	}
+	return fs.Readlink(name)
+}
+
+func (c *copyOnWriteFS) Remove(name string) error {
+	_, overlayErr := c.overlay.Lstat(name)
+	if overlayErr == nil {
+		if err := c.overlay.Remove(name); err != nil {
+			return err
+		}
+	} else {
+		// name is not in overlay, so the removal can only succeed if it
+		// exists in base; surface base's error (including NotExist) as
+		// Remove's own.
+		if _, err := c.base.Lstat(name); err != nil {
+			return err
+		}
+	}
+	c.whiteout(name)
+	return nil
+}
+
+func (c *copyOnWriteFS) RemoveAll(name string) error {
+	if _, err := c.overlay.Lstat(name); err == nil {
+		if err := c.overlay.RemoveAll(name); err != nil {
+			return err
+		}
+	}
+	c.whiteout(name)
+	return nil
+}
+
+func (c *copyOnWriteFS) Rename(oldpath, newpath string) error {
+	if err := c.copyDirsUp(filepath.Dir(newpath)); err != nil {
+		return err
+	}
+	if err := c.copyUp(oldpath); err != nil {
+		return err
+	}
+	if err := c.overlay.Rename(oldpath, newpath); err != nil {
+		return err
+	}
+	c.whiteout(oldpath)
+	c.undelete(newpath)
+	return nil
+}
+
+func (c *copyOnWriteFS) Stat(name string) (os.FileInfo, error) {
+	fs, err := c.readPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(name)
+}
+
+func (c *copyOnWriteFS) Symlink(oldname, newname string) error {
+	if err := c.copyDirsUp(filepath.Dir(newname)); err != nil {
+		return err
+	}
+	c.undelete(newname)
+	return c.overlay.Symlink(oldname, newname)
+}
+
+func (c *copyOnWriteFS) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	if err := c.copyDirsUp(filepath.Dir(filename)); err != nil {
+		return err
+	}
+	c.undelete(filename)
+	return c.overlay.WriteFile(filename, data, perm)
+}
+
+var _ vfs.FS = &copyOnWriteFS{}
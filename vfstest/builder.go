@@ -0,0 +1,544 @@
+// Package vfstest provides helpers for building and testing vfs.FS trees.
+package vfstest
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfsoverlay"
+)
+
+// A ConflictPolicy determines how a Builder behaves when asked to build a
+// node at a path that already exists with different contents, permissions,
+// or symlink target. An existing entry of a different kind is an error under
+// every policy, except that under ConflictSkip Mkdir leaves an existing
+// non-directory untouched. Hardlink does not consult the policy: an existing
+// entry that is not already the same file is always an error.
+type ConflictPolicy int
+
+const (
+	// ConflictError returns an error when an existing entry differs from
+	// the requested one. This is the default.
+	ConflictError ConflictPolicy = iota
+	// ConflictOverwrite replaces the existing entry with the requested
+	// one.
+	ConflictOverwrite
+	// ConflictSkip leaves the existing entry untouched and reports no
+	// error.
+	ConflictSkip
+)
+
+// A Dir represents the expected state of a directory and, recursively, its
+// entries.
+type Dir struct {
+	Perm    os.FileMode
+	Uid     *int
+	Gid     *int
+	Entries map[string]interface{}
+}
+
+// A File represents the expected state of a file.
+type File struct {
+	Perm     os.FileMode
+	Uid      *int
+	Gid      *int
+	Contents []byte
+}
+
+// A Symlink represents the expected state of a symbolic link.
+type Symlink struct {
+	Target string
+}
+
+// A Hardlink represents a hard link to a path that must already have been
+// built elsewhere in the same root.
+type Hardlink struct {
+	Target string
+}
+
+// A Builder builds a root in an vfs.FS from a description, and can be reused
+// across multiple Build calls.
+type Builder struct {
+	umask           os.FileMode
+	logger          *log.Logger
+	defaultDirPerm  os.FileMode
+	defaultFilePerm os.FileMode
+	uid             *int
+	gid             *int
+	clock           func() time.Time
+	onConflict      ConflictPolicy
+	overlayBase     vfs.FS
+}
+
+// A BuilderOption sets an option on a Builder.
+type BuilderOption func(*Builder)
+
+// BuilderUmask sets the umask applied to every permission the Builder uses,
+// whether given explicitly or taken from the defaults.
+func BuilderUmask(umask os.FileMode) BuilderOption {
+	return func(b *Builder) { b.umask = umask }
+}
+
+// BuilderVerbose sets whether the Builder logs every operation it performs
+// to os.Stdout. For logging to a different destination, use BuilderLogger.
+func BuilderVerbose(verbose bool) BuilderOption {
+	return func(b *Builder) {
+		if verbose {
+			b.logger = log.New(os.Stdout, "", log.LstdFlags)
+		} else {
+			b.logger = nil
+		}
+	}
+}
+
+// BuilderLogger sets the logger that the Builder logs every operation it
+// performs to. A nil logger disables logging.
+func BuilderLogger(logger *log.Logger) BuilderOption {
+	return func(b *Builder) { b.logger = logger }
+}
+
+// BuilderDefaultDirPerm sets the permissions used for directories created
+// without an explicit Perm.
+func BuilderDefaultDirPerm(perm os.FileMode) BuilderOption {
+	return func(b *Builder) { b.defaultDirPerm = perm }
+}
+
+// BuilderDefaultFilePerm sets the permissions used for files created
+// without an explicit Perm.
+func BuilderDefaultFilePerm(perm os.FileMode) BuilderOption {
+	return func(b *Builder) { b.defaultFilePerm = perm }
+}
+
+// BuilderChown sets the uid and gid applied to every *File and *Dir node
+// built that does not specify its own Uid or Gid. Other nodes, and
+// directories created implicitly for deeper paths, are not chowned.
+func BuilderChown(uid, gid int) BuilderOption {
+	return func(b *Builder) { b.uid, b.gid = &uid, &gid }
+}
+
+// BuilderClock sets the function used to determine the mtime (and atime)
+// applied to every node after it is built, making Build's output
+// deterministic. Symlink and Hardlink nodes are not touched. If unset, nodes
+// are left with whatever time the underlying vfs.FS gave them.
+func BuilderClock(clock func() time.Time) BuilderOption {
+	return func(b *Builder) { b.clock = clock }
+}
+
+// BuilderOnConflict sets the policy used when a node to be built already
+// exists with different contents, permissions, or target.
+func BuilderOnConflict(policy ConflictPolicy) BuilderOption {
+	return func(b *Builder) { b.onConflict = policy }
+}
+
+// BuilderOverlay makes NewTempFS stack its temporary filesystem as a
+// writable copy-on-write overlay on top of base, so that root is built into
+// the overlay while reads of paths not present in root fall through to
+// base. It has no effect on Builder.Build called directly on an existing
+// vfs.FS.
+func BuilderOverlay(base vfs.FS) BuilderOption {
+	return func(b *Builder) { b.overlayBase = base }
+}
+
+// NewBuilder returns a new Builder with the given options.
+func NewBuilder(options ...BuilderOption) *Builder {
+	b := &Builder{
+		umask:           022,
+		defaultDirPerm:  0777,
+		defaultFilePerm: 0666,
+	}
+	for _, option := range options {
+		option(b)
+	}
+	return b
+}
+
+// NewTempFS returns a new vfs.FS rooted in a temporary directory, built
+// according to root, and a cleanup function that removes the temporary
+// directory. If building fails, fs and cleanup are still valid and the
+// caller is responsible for calling cleanup.
+func NewTempFS(root interface{}, options ...BuilderOption) (vfs.FS, func(), error) {
+	tempFS, cleanup, err := newTempFS()
+	if err != nil {
+		return tempFS, cleanup, err
+	}
+	b := NewBuilder(options...)
+	fs := tempFS
+	if b.overlayBase != nil {
+		fs = vfsoverlay.NewCopyOnWriteFS(b.overlayBase, tempFS)
+	}
+	if err := b.Build(fs, root); err != nil {
+		return fs, cleanup, err
+	}
+	return fs, cleanup, nil
+}
+
+func (b *Builder) logf(format string, args ...interface{}) {
+	if b.logger != nil {
+		b.logger.Printf(format, args...)
+	}
+}
+
+// touch sets path's mtime and atime to b.clock(), if a clock is set.
+func (b *Builder) touch(fs vfs.FS, path string) error {
+	if b.clock == nil {
+		return nil
+	}
+	now := b.clock()
+	return fs.Chtimes(path, now, now)
+}
+
+// A pendingHardlink records a *Hardlink node encountered while walking a
+// root, to be created once every other node has been built.
+type pendingHardlink struct {
+	path   string
+	target string
+}
+
+// Build builds root in fs.
+//
+// Hardlink nodes are built last, after every other node in root, since a
+// hard link's target may be a sibling built later in the same directory
+// (or map, whose iteration order Go randomizes) and must already exist.
+func (b *Builder) Build(fs vfs.FS, root interface{}) error {
+	var hardlinks []pendingHardlink
+	if err := b.build(fs, "/", root, &hardlinks); err != nil {
+		return err
+	}
+	for _, hardlink := range hardlinks {
+		if err := b.Hardlink(fs, hardlink.target, hardlink.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *Builder) build(fs vfs.FS, path string, node interface{}, hardlinks *[]pendingHardlink) error {
+	switch node := node.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		for _, name := range sortedByDepth(node) {
+			entry := node[name]
+			entryPath := join(path, name)
+			if err := b.MkdirAll(fs, filepath.Dir(entryPath), b.defaultDirPerm&^b.umask); err != nil {
+				return err
+			}
+			if err := b.build(fs, entryPath, entry, hardlinks); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]string:
+		for _, name := range sortedByDepthStrings(node) {
+			contents := node[name]
+			entryPath := join(path, name)
+			if err := b.MkdirAll(fs, filepath.Dir(entryPath), b.defaultDirPerm&^b.umask); err != nil {
+				return err
+			}
+			if err := b.WriteFile(fs, entryPath, []byte(contents), b.defaultFilePerm&^b.umask); err != nil {
+				return err
+			}
+			if err := b.touch(fs, entryPath); err != nil {
+				return err
+			}
+		}
+		return nil
+	case string:
+		if err := b.WriteFile(fs, path, []byte(node), b.defaultFilePerm&^b.umask); err != nil {
+			return err
+		}
+		return b.touch(fs, path)
+	case []byte:
+		if err := b.WriteFile(fs, path, node, b.defaultFilePerm&^b.umask); err != nil {
+			return err
+		}
+		return b.touch(fs, path)
+	case *File:
+		perm := node.Perm
+		if perm == 0 {
+			perm = b.defaultFilePerm &^ b.umask
+		}
+		if err := b.WriteFile(fs, path, node.Contents, perm); err != nil {
+			return err
+		}
+		if err := b.chown(fs, path, node.Uid, node.Gid); err != nil {
+			return err
+		}
+		return b.touch(fs, path)
+	case *Dir:
+		perm := node.Perm
+		if perm == 0 {
+			perm = b.defaultDirPerm &^ b.umask
+		}
+		if err := b.Mkdir(fs, path, perm); err != nil {
+			return err
+		}
+		if err := b.chown(fs, path, node.Uid, node.Gid); err != nil {
+			return err
+		}
+		for _, name := range sortedByDepth(node.Entries) {
+			if err := b.build(fs, join(path, name), node.Entries[name], hardlinks); err != nil {
+				return err
+			}
+		}
+		return b.touch(fs, path)
+	case *Symlink:
+		// Not touched: Chtimes follows the link, so it would change the
+		// target's times, or fail if the target has not been built yet.
+		return b.Symlink(fs, node.Target, path)
+	case *Hardlink:
+		*hardlinks = append(*hardlinks, pendingHardlink{path: path, target: node.Target})
+		return nil
+	default:
+		return fmt.Errorf("vfstest: %s: unsupported type %T", path, node)
+	}
+}
+
+func (b *Builder) chown(fs vfs.FS, path string, uid, gid *int) error {
+	if uid == nil {
+		uid = b.uid
+	}
+	if gid == nil {
+		gid = b.gid
+	}
+	if uid == nil && gid == nil {
+		return nil
+	}
+	u, g := -1, -1
+	if uid != nil {
+		u = *uid
+	}
+	if gid != nil {
+		g = *gid
+	}
+	b.logf("vfstest: Chown(%q, %d, %d)", path, u, g)
+	return fs.Chown(path, u, g)
+}
+
+// overlayLstat is like fs.Lstat, except that when fs is composed from a
+// BuilderOverlay, a path that exists only in the read-only base layer is
+// reported as not existing. Base is background fixture state the Builder
+// did not create; root should be able to write such a path without it
+// being treated as a conflict with something the Builder itself built.
+func overlayLstat(fs vfs.FS, path string) (os.FileInfo, error) {
+	if layer, err := vfsoverlay.Layer(fs, path); err == nil && layer != "overlay" {
+		return nil, os.ErrNotExist
+	}
+	return fs.Lstat(path)
+}
+
+// Mkdir creates path in fs with permissions perm. If path already exists as
+// a directory with the same permissions, Mkdir is a no-op.
+func (b *Builder) Mkdir(fs vfs.FS, path string, perm os.FileMode) error {
+	perm &^= b.umask
+	info, err := overlayLstat(fs, path)
+	switch {
+	case err == nil && info.IsDir() && info.Mode().Perm() == perm:
+		return nil
+	case err == nil && info.IsDir():
+		switch b.onConflict {
+		case ConflictOverwrite:
+			b.logf("vfstest: Chmod(%q, %o)", path, perm)
+			return fs.Chmod(path, perm)
+		case ConflictSkip:
+			return nil
+		default:
+			return fmt.Errorf("vfstest: %s: already exists and is not a directory with permissions %o", path, perm)
+		}
+	case err == nil:
+		if b.onConflict == ConflictSkip {
+			return nil
+		}
+		return fmt.Errorf("vfstest: %s: already exists and is not a directory with permissions %o", path, perm)
+	case os.IsNotExist(err):
+		b.logf("vfstest: Mkdir(%q, %o)", path, perm)
+		if err := fs.Mkdir(path, perm); err != nil {
+			return err
+		}
+		// The process umask, not just b.umask, affects os.Mkdir, so force
+		// the final permissions to exactly perm regardless of what the
+		// real umask let through.
+		return fs.Chmod(path, perm)
+	default:
+		return err
+	}
+}
+
+// MkdirAll creates path and any missing parents in fs with permissions
+// perm, in the same manner as os.MkdirAll.
+func (b *Builder) MkdirAll(fs vfs.FS, path string, perm os.FileMode) error {
+	perm &^= b.umask
+	if path == "/" || path == "." {
+		return nil
+	}
+	info, err := fs.Lstat(path)
+	if err == nil {
+		if info.IsDir() {
+			return nil
+		}
+		return fmt.Errorf("vfstest: %s: already exists and is not a directory", path)
+	}
+	if !os.IsNotExist(err) {
+		return err
+	}
+	if err := b.MkdirAll(fs, filepath.Dir(path), perm); err != nil {
+		return err
+	}
+	b.logf("vfstest: Mkdir(%q, %o)", path, perm)
+	if err := fs.Mkdir(path, perm); err != nil {
+		return err
+	}
+	// As in Mkdir, the process umask can silently mask perm, so enforce it
+	// explicitly rather than trusting what os.Mkdir produced.
+	return fs.Chmod(path, perm)
+}
+
+// WriteFile writes contents to path in fs with permissions perm. If path
+// already exists with the same contents and permissions, WriteFile is a
+// no-op.
+func (b *Builder) WriteFile(fs vfs.FS, path string, contents []byte, perm os.FileMode) error {
+	perm &^= b.umask
+	info, err := overlayLstat(fs, path)
+	switch {
+	case err == nil && info.IsDir():
+		return fmt.Errorf("vfstest: %s: already exists and is a directory", path)
+	case err == nil:
+		existingContents, readErr := fs.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		if string(existingContents) == string(contents) && info.Mode().Perm() == perm {
+			return nil
+		}
+		switch b.onConflict {
+		case ConflictOverwrite:
+			b.logf("vfstest: WriteFile(%q, _, %o)", path, perm)
+			if err := fs.WriteFile(path, contents, perm); err != nil {
+				return err
+			}
+			return fs.Chmod(path, perm)
+		case ConflictSkip:
+			return nil
+		default:
+			return fmt.Errorf("vfstest: %s: already exists with different contents or permissions", path)
+		}
+	case os.IsNotExist(err):
+		b.logf("vfstest: WriteFile(%q, _, %o)", path, perm)
+		if err := fs.WriteFile(path, contents, perm); err != nil {
+			return err
+		}
+		// os.OpenFile's perm argument is masked by the process umask, so
+		// enforce perm explicitly rather than trusting what it produced.
+		return fs.Chmod(path, perm)
+	default:
+		return err
+	}
+}
+
+// Symlink creates a symbolic link at path pointing to target. If path
+// already exists as a symlink to target, Symlink is a no-op; if it exists as
+// a symlink to a different target, the Builder's ConflictPolicy decides
+// (an error by default), and if it exists as anything else it is an error.
+func (b *Builder) Symlink(fs vfs.FS, target, path string) error {
+	info, err := overlayLstat(fs, path)
+	switch {
+	case err == nil && info.Mode()&os.ModeSymlink == 0:
+		return fmt.Errorf("vfstest: %s: already exists and is not a symlink", path)
+	case err == nil:
+		existingTarget, readErr := fs.Readlink(path)
+		if readErr != nil {
+			return readErr
+		}
+		if existingTarget == target {
+			return nil
+		}
+		switch b.onConflict {
+		case ConflictOverwrite:
+			if err := fs.Remove(path); err != nil {
+				return err
+			}
+			b.logf("vfstest: Symlink(%q, %q)", target, path)
+			return fs.Symlink(target, path)
+		case ConflictSkip:
+			return nil
+		default:
+			return fmt.Errorf("vfstest: %s: already exists as a symlink to %q, want %q", path, existingTarget, target)
+		}
+	case os.IsNotExist(err):
+		b.logf("vfstest: Symlink(%q, %q)", target, path)
+		return fs.Symlink(target, path)
+	default:
+		return err
+	}
+}
+
+// Hardlink creates a hard link at path pointing to target. If path already
+// exists, it is compared for identity with target and Hardlink is a no-op
+// if they are already the same file.
+func (b *Builder) Hardlink(fs vfs.FS, target, path string) error {
+	if _, err := fs.Lstat(path); err == nil {
+		targetInfo, err := fs.Stat(target)
+		if err != nil {
+			return err
+		}
+		pathInfo, err := fs.Stat(path)
+		if err != nil {
+			return err
+		}
+		if os.SameFile(targetInfo, pathInfo) {
+			return nil
+		}
+		return fmt.Errorf("vfstest: %s: already exists and is not a hard link to %q", path, target)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	b.logf("vfstest: Link(%q, %q)", target, path)
+	return fs.Link(target, path)
+}
+
+func join(dir, name string) string {
+	if dir == "/" {
+		return "/" + name
+	}
+	return dir + "/" + name
+}
+
+// byDepth sorts names so that shallower paths sort first, and paths at the
+// same depth sort lexicographically. This ensures that, within a single
+// map literal, an explicit entry for a directory (e.g. a *Dir with
+// non-default permissions) is always built before a sibling entry whose
+// deeper path would otherwise auto-vivify that same directory with default
+// permissions, regardless of Go's randomized map iteration order.
+func byDepth(names []string) {
+	sort.Slice(names, func(i, j int) bool {
+		di, dj := strings.Count(names[i], "/"), strings.Count(names[j], "/")
+		if di != dj {
+			return di < dj
+		}
+		return names[i] < names[j]
+	})
+}
+
+func sortedByDepth(node map[string]interface{}) []string {
+	names := make([]string, 0, len(node))
+	for name := range node {
+		names = append(names, name)
+	}
+	byDepth(names)
+	return names
+}
+
+func sortedByDepthStrings(node map[string]string) []string {
+	names := make([]string, 0, len(node))
+	for name := range node {
+		names = append(names, name)
+	}
+	byDepth(names)
+	return names
+}
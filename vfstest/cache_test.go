@@ -0,0 +1,100 @@
+package vfstest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/twpayne/go-vfs/vfsoverlay"
+)
+
+func TestCacheOnReadFSPopulatesCache(t *testing.T) {
+	base, baseCleanup, err := NewTempFS(map[string]interface{}{
+		"foo": "base foo",
+	})
+	defer baseCleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, cacheCleanup, err := NewTempFS(nil)
+	defer cacheCleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := vfsoverlay.NewCacheOnReadFS(base, cache, 0)
+	if contents, err := fs.ReadFile("/foo"); err != nil || string(contents) != "base foo" {
+		t.Fatalf("fs.ReadFile(\"/foo\") == %q, %v, want %q, <nil>", contents, err, "base foo")
+	}
+	if contents, err := cache.ReadFile("/foo"); err != nil || string(contents) != "base foo" {
+		t.Fatalf("cache.ReadFile(\"/foo\") == %q, %v, want %q, <nil>", contents, err, "base foo")
+	}
+
+	// With no ttl, the cached copy never goes stale, so a change made
+	// directly to base (bypassing fs) must not be observed through fs.
+	if err := base.WriteFile("/foo", []byte("changed base foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if contents, err := fs.ReadFile("/foo"); err != nil || string(contents) != "base foo" {
+		t.Errorf("fs.ReadFile(\"/foo\") == %q, %v, want %q, <nil>", contents, err, "base foo")
+	}
+}
+
+func TestCacheOnReadFSStaleAfterTTL(t *testing.T) {
+	base, baseCleanup, err := NewTempFS(map[string]interface{}{
+		"foo": "base foo",
+	})
+	defer baseCleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, cacheCleanup, err := NewTempFS(nil)
+	defer cacheCleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const ttl = 20 * time.Millisecond
+	fs := vfsoverlay.NewCacheOnReadFS(base, cache, ttl)
+	if contents, err := fs.ReadFile("/foo"); err != nil || string(contents) != "base foo" {
+		t.Fatalf("fs.ReadFile(\"/foo\") == %q, %v, want %q, <nil>", contents, err, "base foo")
+	}
+
+	if err := base.WriteFile("/foo", []byte("updated base foo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(4 * ttl)
+
+	if contents, err := fs.ReadFile("/foo"); err != nil || string(contents) != "updated base foo" {
+		t.Errorf("fs.ReadFile(\"/foo\") == %q, %v, want %q, <nil>", contents, err, "updated base foo")
+	}
+}
+
+func TestCacheOnReadFSWriteInvalidatesCache(t *testing.T) {
+	base, baseCleanup, err := NewTempFS(map[string]interface{}{
+		"foo": "base foo",
+	})
+	defer baseCleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache, cacheCleanup, err := NewTempFS(nil)
+	defer cacheCleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := vfsoverlay.NewCacheOnReadFS(base, cache, 0)
+	if _, err := fs.ReadFile("/foo"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.WriteFile("/foo", []byte("written via fs"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if contents, err := base.ReadFile("/foo"); err != nil || string(contents) != "written via fs" {
+		t.Fatalf("base.ReadFile(\"/foo\") == %q, %v, want %q, <nil>", contents, err, "written via fs")
+	}
+	if contents, err := fs.ReadFile("/foo"); err != nil || string(contents) != "written via fs" {
+		t.Errorf("fs.ReadFile(\"/foo\") == %q, %v, want %q, <nil>", contents, err, "written via fs")
+	}
+}
@@ -0,0 +1,200 @@
+package vfstest
+
+import (
+	"os"
+	"testing"
+
+	"github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfsoverlay"
+)
+
+// A Test is a single assertion made against a path in a vfs.FS.
+type Test func(t *testing.T, fs vfs.FS, path string)
+
+// RunTest runs tests against fs, relative to path. tests may be a Test, a
+// []Test, a []interface{} (whose elements are themselves passed to
+// RunTest), or a map[string]Test / map[string]interface{}, in which case
+// each entry is run in its own subtest named after its key.
+func RunTest(t *testing.T, fs vfs.FS, path string, tests interface{}) {
+	switch tests := tests.(type) {
+	case Test:
+		tests(t, fs, path)
+	case []Test:
+		for _, test := range tests {
+			RunTest(t, fs, path, test)
+		}
+	case []interface{}:
+		for _, test := range tests {
+			RunTest(t, fs, path, test)
+		}
+	case map[string]Test:
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				RunTest(t, fs, path, test)
+			})
+		}
+	case map[string]interface{}:
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				RunTest(t, fs, path, test)
+			})
+		}
+	default:
+		t.Fatalf("vfstest: unsupported tests type %T", tests)
+	}
+}
+
+// TestPath returns a Test that runs tests against path, all in a single
+// subtest named after path.
+func TestPath(path string, tests ...Test) Test {
+	return func(t *testing.T, fs vfs.FS, _ string) {
+		t.Run(path, func(t *testing.T) {
+			for _, test := range tests {
+				test(t, fs, path)
+			}
+		})
+	}
+}
+
+// TestDoesNotExist tests that path does not exist.
+func TestDoesNotExist(t *testing.T, fs vfs.FS, path string) {
+	_, err := fs.Lstat(path)
+	if !os.IsNotExist(err) {
+		t.Errorf("fs.Lstat(%q) == _, %v, want _, does-not-exist error", path, err)
+	}
+}
+
+// TestIsDir tests that path is a directory.
+func TestIsDir(t *testing.T, fs vfs.FS, path string) {
+	info, err := fs.Lstat(path)
+	if err != nil {
+		t.Errorf("fs.Lstat(%q) == _, %v, want _, <nil>", path, err)
+		return
+	}
+	if !info.IsDir() {
+		t.Errorf("fs.Lstat(%q).IsDir() == false, want true", path)
+	}
+}
+
+// TestIsSymlink tests that path is a symbolic link.
+func TestIsSymlink(t *testing.T, fs vfs.FS, path string) {
+	info, err := fs.Lstat(path)
+	if err != nil {
+		t.Errorf("fs.Lstat(%q) == _, %v, want _, <nil>", path, err)
+		return
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("fs.Lstat(%q).Mode()&os.ModeSymlink == 0, want non-zero", path)
+	}
+}
+
+// TestModeIsRegular tests that path is a regular file.
+func TestModeIsRegular(t *testing.T, fs vfs.FS, path string) {
+	info, err := fs.Lstat(path)
+	if err != nil {
+		t.Errorf("fs.Lstat(%q) == _, %v, want _, <nil>", path, err)
+		return
+	}
+	if !info.Mode().IsRegular() {
+		t.Errorf("fs.Lstat(%q).Mode().IsRegular() == false, want true", path)
+	}
+}
+
+// TestModePerm returns a Test that tests that path has permissions perm.
+func TestModePerm(perm os.FileMode) Test {
+	return func(t *testing.T, fs vfs.FS, path string) {
+		info, err := fs.Lstat(path)
+		if err != nil {
+			t.Errorf("fs.Lstat(%q) == _, %v, want _, <nil>", path, err)
+			return
+		}
+		if info.Mode().Perm() != perm {
+			t.Errorf("fs.Lstat(%q).Mode().Perm() == %o, want %o", path, info.Mode().Perm(), perm)
+		}
+	}
+}
+
+// TestSize returns a Test that tests that path has the given size.
+func TestSize(size int64) Test {
+	return func(t *testing.T, fs vfs.FS, path string) {
+		info, err := fs.Lstat(path)
+		if err != nil {
+			t.Errorf("fs.Lstat(%q) == _, %v, want _, <nil>", path, err)
+			return
+		}
+		if info.Size() != size {
+			t.Errorf("fs.Lstat(%q).Size() == %d, want %d", path, info.Size(), size)
+		}
+	}
+}
+
+// TestMinSize returns a Test that tests that path has at least the given
+// size.
+func TestMinSize(size int64) Test {
+	return func(t *testing.T, fs vfs.FS, path string) {
+		info, err := fs.Lstat(path)
+		if err != nil {
+			t.Errorf("fs.Lstat(%q) == _, %v, want _, <nil>", path, err)
+			return
+		}
+		if info.Size() < size {
+			t.Errorf("fs.Lstat(%q).Size() == %d, want >= %d", path, info.Size(), size)
+		}
+	}
+}
+
+// TestContentsString returns a Test that tests that path has the given
+// contents.
+func TestContentsString(contents string) Test {
+	return func(t *testing.T, fs vfs.FS, path string) {
+		actual, err := fs.ReadFile(path)
+		if err != nil {
+			t.Errorf("fs.ReadFile(%q) == _, %v, want _, <nil>", path, err)
+			return
+		}
+		if string(actual) != contents {
+			t.Errorf("fs.ReadFile(%q) == %q, want %q", path, actual, contents)
+		}
+	}
+}
+
+// TestPathInOverlay tests that path is served from the overlay layer of a
+// vfs.FS returned by vfsoverlay.NewCopyOnWriteFS.
+func TestPathInOverlay(t *testing.T, fs vfs.FS, path string) {
+	layer, err := vfsoverlay.Layer(fs, path)
+	if err != nil {
+		t.Errorf("vfsoverlay.Layer(fs, %q) == _, %v, want _, <nil>", path, err)
+		return
+	}
+	if layer != "overlay" {
+		t.Errorf("vfsoverlay.Layer(fs, %q) == %q, want %q", path, layer, "overlay")
+	}
+}
+
+// TestPathInBase tests that path is served from the base layer of a vfs.FS
+// returned by vfsoverlay.NewCopyOnWriteFS.
+func TestPathInBase(t *testing.T, fs vfs.FS, path string) {
+	layer, err := vfsoverlay.Layer(fs, path)
+	if err != nil {
+		t.Errorf("vfsoverlay.Layer(fs, %q) == _, %v, want _, <nil>", path, err)
+		return
+	}
+	if layer != "base" {
+		t.Errorf("vfsoverlay.Layer(fs, %q) == %q, want %q", path, layer, "base")
+	}
+}
+
+// TestSymlinkTarget returns a Test that tests that path is a symlink to
+// target.
+func TestSymlinkTarget(target string) Test {
+	return func(t *testing.T, fs vfs.FS, path string) {
+		actual, err := fs.Readlink(path)
+		if err != nil {
+			t.Errorf("fs.Readlink(%q) == _, %v, want _, <nil>", path, err)
+			return
+		}
+		if actual != target {
+			t.Errorf("fs.Readlink(%q) == %q, want %q", path, actual, target)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+// +build !windows
+
+package vfstest
+
+import (
+	"syscall"
+	"testing"
+
+	"github.com/twpayne/go-vfs"
+)
+
+// TestSysNlink returns a Test that tests that path's underlying
+// syscall.Stat_t reports the given number of hard links.
+func TestSysNlink(nlink int) Test {
+	return func(t *testing.T, fs vfs.FS, path string) {
+		info, err := fs.Lstat(path)
+		if err != nil {
+			t.Errorf("fs.Lstat(%q) == _, %v, want _, <nil>", path, err)
+			return
+		}
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			t.Errorf("fs.Lstat(%q).Sys().(*syscall.Stat_t) failed", path)
+			return
+		}
+		if int(stat.Nlink) != nlink {
+			t.Errorf("fs.Lstat(%q).Sys().(*syscall.Stat_t).Nlink == %d, want %d", path, stat.Nlink, nlink)
+		}
+	}
+}
+
+// TestSysUID returns a Test that tests that path's underlying
+// syscall.Stat_t reports the given uid.
+func TestSysUID(uid int) Test {
+	return func(t *testing.T, fs vfs.FS, path string) {
+		info, err := fs.Lstat(path)
+		if err != nil {
+			t.Errorf("fs.Lstat(%q) == _, %v, want _, <nil>", path, err)
+			return
+		}
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			t.Errorf("fs.Lstat(%q).Sys().(*syscall.Stat_t) failed", path)
+			return
+		}
+		if int(stat.Uid) != uid {
+			t.Errorf("fs.Lstat(%q).Sys().(*syscall.Stat_t).Uid == %d, want %d", path, stat.Uid, uid)
+		}
+	}
+}
+
+// TestSysGID returns a Test that tests that path's underlying
+// syscall.Stat_t reports the given gid.
+func TestSysGID(gid int) Test {
+	return func(t *testing.T, fs vfs.FS, path string) {
+		info, err := fs.Lstat(path)
+		if err != nil {
+			t.Errorf("fs.Lstat(%q) == _, %v, want _, <nil>", path, err)
+			return
+		}
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		if !ok {
+			t.Errorf("fs.Lstat(%q).Sys().(*syscall.Stat_t) failed", path)
+			return
+		}
+		if int(stat.Gid) != gid {
+			t.Errorf("fs.Lstat(%q).Sys().(*syscall.Stat_t).Gid == %d, want %d", path, stat.Gid, gid)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package vfstest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// BuilderFromTar reads a tar archive from r and returns the equivalent root
+// in the map[string]interface{} shape accepted by Builder.Build, suitable
+// for seeding a NewTempFS from a golden tarball.
+func BuilderFromTar(r io.Reader) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name := archiveName(header.Name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			root[name] = &Dir{Perm: os.FileMode(header.Mode).Perm()}
+		case tar.TypeSymlink:
+			root[name] = &Symlink{Target: header.Linkname}
+		case tar.TypeReg, tar.TypeRegA:
+			contents, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, err
+			}
+			root[name] = &File{Perm: os.FileMode(header.Mode).Perm(), Contents: contents}
+		default:
+			return nil, fmt.Errorf("vfstest: %s: unsupported tar entry type %v", header.Name, header.Typeflag)
+		}
+	}
+	return root, nil
+}
+
+// BuilderFromZip reads a zip archive from zr and returns the equivalent
+// root in the map[string]interface{} shape accepted by Builder.Build.
+func BuilderFromZip(zr *zip.Reader) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	for _, zf := range zr.File {
+		name := archiveName(zf.Name)
+		info := zf.FileInfo()
+		switch {
+		case info.IsDir():
+			root[name] = &Dir{Perm: info.Mode().Perm()}
+		case info.Mode()&os.ModeSymlink != 0:
+			rc, err := zf.Open()
+			if err != nil {
+				return nil, err
+			}
+			target, err := ioutil.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+			root[name] = &Symlink{Target: string(target)}
+		default:
+			rc, err := zf.Open()
+			if err != nil {
+				return nil, err
+			}
+			contents, err := ioutil.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+			root[name] = &File{Perm: info.Mode().Perm(), Contents: contents}
+		}
+	}
+	return root, nil
+}
+
+// BuilderFromOSDir walks the real directory tree rooted at root and returns
+// its equivalent in the map[string]interface{} shape accepted by
+// Builder.Build, preserving permissions, symlinks, and, where the platform
+// makes it available, ownership.
+func BuilderFromOSDir(root string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	err := filepath.Walk(root, func(osPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, osPath)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		name := archiveName(rel)
+		uid, gid := sysOwner(info)
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			target, err := os.Readlink(osPath)
+			if err != nil {
+				return err
+			}
+			result[name] = &Symlink{Target: target}
+		case info.IsDir():
+			result[name] = &Dir{Perm: info.Mode().Perm(), Uid: uid, Gid: gid}
+		default:
+			contents, err := ioutil.ReadFile(osPath)
+			if err != nil {
+				return err
+			}
+			result[name] = &File{Perm: info.Mode().Perm(), Uid: uid, Gid: gid, Contents: contents}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// archiveName converts a slash-separated archive or relative OS path into
+// the absolute, single-leading-slash form used as a key in a Builder root
+// map.
+func archiveName(name string) string {
+	return "/" + path.Clean(filepath.ToSlash(name))
+}
@@ -0,0 +1,11 @@
+// +build windows
+
+package vfstest
+
+import "os"
+
+// sysOwner returns the uid and gid of info. On this platform, ownership is
+// not exposed via os.FileInfo, so it always returns nil, nil.
+func sysOwner(info os.FileInfo) (*int, *int) {
+	return nil, nil
+}
@@ -0,0 +1,140 @@
+package vfstest
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+// TestBuilderFromTarNonDefaultDirPerm guards against a race between a
+// directory's own entry and the implicit MkdirAll triggered by one of its
+// children: BuilderFromTar flattens a whole tree into sibling map entries,
+// so a directory with non-default permissions must still build reliably
+// however the map happens to be iterated.
+func TestBuilderFromTarNonDefaultDirPerm(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		var buf bytes.Buffer
+		tw := tar.NewWriter(&buf)
+		if err := tw.WriteHeader(&tar.Header{Name: "dir", Typeflag: tar.TypeDir, Mode: 0700}); err != nil {
+			t.Fatal(err)
+		}
+		contents := []byte("qux")
+		if err := tw.WriteHeader(&tar.Header{Name: "dir/file.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(contents))}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(contents); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		root, err := BuilderFromTar(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fs, cleanup, err := NewTempFS(root)
+		if err != nil {
+			cleanup()
+			t.Fatalf("run %d: NewTempFS(root) == _, _, %v, want _, _, <nil>", i, err)
+		}
+		RunTest(t, fs, "", []Test{
+			TestPath("/dir", TestIsDir, TestModePerm(0700)),
+			TestPath("/dir/file.txt", TestModeIsRegular, TestModePerm(0644), TestContentsString("qux")),
+		})
+		cleanup()
+	}
+}
+
+func TestBuilderFromTar(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	contents := []byte("bar")
+	if err := tw.WriteHeader(&tar.Header{Name: "foo", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(contents))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(contents); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "dir", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := BuilderFromTar(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, cleanup, err := NewTempFS(root)
+	defer cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	RunTest(t, fs, "", []Test{
+		TestPath("/foo", TestModeIsRegular, TestModePerm(0644), TestContentsString("bar")),
+		TestPath("/dir", TestIsDir, TestModePerm(0755)),
+		TestPath("/link", TestIsSymlink, TestSymlinkTarget("foo")),
+	})
+}
+
+func TestBuilderFromZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	fw, err := zw.Create("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fw.Write([]byte("bar")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := BuilderFromZip(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, cleanup, err := NewTempFS(root)
+	defer cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	RunTest(t, fs, "", TestPath("/foo", TestModeIsRegular, TestContentsString("bar")))
+}
+
+func TestBuilderFromOSDir(t *testing.T) {
+	srcFS, srcCleanup, err := NewTempFS(map[string]interface{}{
+		"foo": "bar",
+		"dir": &Dir{Perm: 0755},
+	})
+	defer srcCleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcRoot := srcFS.(*rootedFS).root
+
+	root, err := BuilderFromOSDir(srcRoot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, cleanup, err := NewTempFS(root)
+	defer cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	RunTest(t, fs, "", []Test{
+		TestPath("/foo", TestModeIsRegular, TestContentsString("bar")),
+		TestPath("/dir", TestIsDir, TestModePerm(0755)),
+	})
+}
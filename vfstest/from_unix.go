@@ -0,0 +1,19 @@
+// +build !windows
+
+package vfstest
+
+import (
+	"os"
+	"syscall"
+)
+
+// sysOwner returns the uid and gid of info, if its underlying Sys() value
+// exposes them.
+func sysOwner(info os.FileInfo) (*int, *int) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil, nil
+	}
+	uid, gid := int(stat.Uid), int(stat.Gid)
+	return &uid, &gid
+}
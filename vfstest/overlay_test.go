@@ -0,0 +1,101 @@
+package vfstest
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestBuilderOverlay(t *testing.T) {
+	base, baseCleanup, err := NewTempFS(map[string]interface{}{
+		"foo": "base foo",
+		"bar": "base bar",
+	})
+	defer baseCleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs, cleanup, err := NewTempFS(map[string]interface{}{
+		"bar": "overlay bar",
+	}, BuilderOverlay(base))
+	defer cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	RunTest(t, fs, "", []Test{
+		TestPath("/foo", TestPathInBase, TestContentsString("base foo")),
+		TestPath("/bar", TestPathInOverlay, TestContentsString("overlay bar")),
+	})
+}
+
+// TestBuilderOverlayReadDir verifies that ReadDir merges base and overlay
+// entries instead of only listing whichever layer happens to hold the
+// directory itself, which is what copying a single unrelated file up into
+// the overlay would otherwise expose.
+func TestBuilderOverlayReadDir(t *testing.T) {
+	base, baseCleanup, err := NewTempFS(map[string]interface{}{
+		"foo": "base foo",
+		"bar": "base bar",
+	})
+	defer baseCleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs, cleanup, err := NewTempFS(map[string]interface{}{}, BuilderOverlay(base))
+	defer cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.WriteFile("/baz", []byte("overlay baz"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := fs.ReadDir("/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	want := []string{"bar", "baz", "foo"}
+	if len(names) != len(want) {
+		t.Fatalf("fs.ReadDir(\"/\") names == %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("fs.ReadDir(\"/\") names == %v, want %v", names, want)
+		}
+	}
+}
+
+// TestBuilderOverlayRemoveOverlayOnly verifies that removing a path that
+// exists only in the overlay (never in base) succeeds without leaking
+// base's not-exist error.
+func TestBuilderOverlayRemoveOverlayOnly(t *testing.T) {
+	base, baseCleanup, err := NewTempFS(map[string]interface{}{
+		"foo": "base foo",
+	})
+	defer baseCleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs, cleanup, err := NewTempFS(map[string]interface{}{}, BuilderOverlay(base))
+	defer cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fs.WriteFile("/onlyoverlay", []byte("bar"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.Remove("/onlyoverlay"); err != nil {
+		t.Fatalf("fs.Remove(\"/onlyoverlay\") == %v, want <nil>", err)
+	}
+	RunTest(t, fs, "", TestPath("/onlyoverlay", TestDoesNotExist))
+}
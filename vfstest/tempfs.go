@@ -0,0 +1,88 @@
+package vfstest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/twpayne/go-vfs"
+)
+
+// rootedFS is a vfs.FS that rewrites every path so that it is rooted under
+// a temporary directory on the real filesystem. It lets tests refer to
+// absolute paths like /home/user/.bashrc without touching the real /home.
+type rootedFS struct {
+	root string
+}
+
+func (r *rootedFS) real(name string) string {
+	return filepath.Join(r.root, filepath.FromSlash(name))
+}
+
+func (r *rootedFS) Chmod(name string, mode os.FileMode) error { return os.Chmod(r.real(name), mode) }
+func (r *rootedFS) Chown(name string, uid, gid int) error     { return os.Chown(r.real(name), uid, gid) }
+func (r *rootedFS) Chtimes(name string, atime, mtime time.Time) error {
+	return os.Chtimes(r.real(name), atime, mtime)
+}
+func (r *rootedFS) Create(name string) (*os.File, error) { return os.Create(r.real(name)) }
+func (r *rootedFS) Glob(pattern string) ([]string, error) {
+	matches, err := filepath.Glob(r.real(pattern))
+	if err != nil {
+		return nil, err
+	}
+	for i, match := range matches {
+		rel, err := filepath.Rel(r.root, match)
+		if err != nil {
+			return nil, err
+		}
+		matches[i] = filepath.ToSlash(rel)
+	}
+	return matches, nil
+}
+func (r *rootedFS) Link(oldname, newname string) error {
+	return os.Link(r.real(oldname), r.real(newname))
+}
+func (r *rootedFS) Lstat(name string) (os.FileInfo, error) { return os.Lstat(r.real(name)) }
+func (r *rootedFS) Mkdir(name string, perm os.FileMode) error {
+	return os.Mkdir(r.real(name), perm)
+}
+func (r *rootedFS) Open(name string) (*os.File, error) { return os.Open(r.real(name)) }
+func (r *rootedFS) OpenFile(name string, flag int, perm os.FileMode) (*os.File, error) {
+	return os.OpenFile(r.real(name), flag, perm)
+}
+func (r *rootedFS) PathSeparator() rune { return os.PathSeparator }
+func (r *rootedFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(r.real(dirname))
+}
+func (r *rootedFS) ReadFile(filename string) ([]byte, error) { return ioutil.ReadFile(r.real(filename)) }
+func (r *rootedFS) Readlink(name string) (string, error)    { return os.Readlink(r.real(name)) }
+func (r *rootedFS) Remove(name string) error                { return os.Remove(r.real(name)) }
+func (r *rootedFS) RemoveAll(name string) error              { return os.RemoveAll(r.real(name)) }
+func (r *rootedFS) Rename(oldpath, newpath string) error {
+	return os.Rename(r.real(oldpath), r.real(newpath))
+}
+func (r *rootedFS) Stat(name string) (os.FileInfo, error) { return os.Stat(r.real(name)) }
+func (r *rootedFS) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, r.real(newname))
+}
+func (r *rootedFS) WriteFile(filename string, data []byte, perm os.FileMode) error {
+	return ioutil.WriteFile(r.real(filename), data, perm)
+}
+
+var _ vfs.FS = &rootedFS{}
+
+// newTempFS returns a new vfs.FS rooted in a freshly-created temporary
+// directory, and a cleanup function that removes it.
+func newTempFS() (vfs.FS, func(), error) {
+	tempDir, err := ioutil.TempDir("", "vfstest")
+	cleanup := func() {
+		if tempDir != "" {
+			os.RemoveAll(tempDir)
+		}
+	}
+	if err != nil {
+		return nil, cleanup, err
+	}
+	return &rootedFS{root: tempDir}, cleanup, nil
+}
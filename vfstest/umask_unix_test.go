@@ -0,0 +1,34 @@
+// +build !windows
+
+package vfstest
+
+import (
+	"syscall"
+	"testing"
+)
+
+// TestBuildIgnoresProcessUmask verifies that Builder.Build produces the
+// exact permissions given in the root literal even when the real process
+// umask would otherwise mask them, per the os.Mkdir/os.OpenFile umask
+// behavior described in https://github.com/syncthing/syncthing/issues/6551.
+func TestBuildIgnoresProcessUmask(t *testing.T) {
+	oldUmask := syscall.Umask(0077)
+	defer syscall.Umask(oldUmask)
+
+	fs, cleanup, err := NewTempFS(map[string]interface{}{
+		"dir": &Dir{
+			Perm: 0755,
+			Entries: map[string]interface{}{
+				"file": &File{Perm: 0644, Contents: []byte("hello")},
+			},
+		},
+	})
+	defer cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	RunTest(t, fs, "", []Test{
+		TestPath("/dir", TestIsDir, TestModePerm(0755)),
+		TestPath("/dir/file", TestModeIsRegular, TestModePerm(0644)),
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/twpayne/go-vfs"
 )
@@ -71,6 +72,19 @@ func TestBuilderBuild(t *testing.T) {
 				TestPath("/foo/bar", TestModeIsRegular, TestModePerm(0644), TestSize(3), TestContentsString("baz")),
 			},
 		},
+		{
+			name:  "symlink_and_hardlink",
+			umask: 022,
+			root: map[string]interface{}{
+				"foo": "bar",
+				"baz": &Symlink{Target: "foo"},
+				"qux": &Hardlink{Target: "/foo"},
+			},
+			tests: []Test{
+				TestPath("/baz", TestIsSymlink, TestSymlinkTarget("foo")),
+				TestPath("/qux", TestModeIsRegular, TestContentsString("bar"), TestSysNlink(2)),
+			},
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			fs, cleanup, err := NewTempFS(tc.root, BuilderUmask(tc.umask), BuilderVerbose(true))
@@ -83,6 +97,94 @@ func TestBuilderBuild(t *testing.T) {
 	}
 }
 
+func TestBuilderOptions(t *testing.T) {
+	t.Run("default_perms", func(t *testing.T) {
+		fs, cleanup, err := NewTempFS(map[string]interface{}{
+			"foo": "bar",
+			"baz": &Dir{},
+		}, BuilderDefaultFilePerm(0600), BuilderDefaultDirPerm(0700))
+		defer cleanup()
+		if err != nil {
+			t.Fatal(err)
+		}
+		RunTest(t, fs, "", []Test{
+			TestPath("/foo", TestModePerm(0600)),
+			TestPath("/baz", TestModePerm(0700)),
+		})
+	})
+
+	t.Run("clock", func(t *testing.T) {
+		mtime := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+		fs, cleanup, err := NewTempFS(map[string]interface{}{
+			"foo": "bar",
+		}, BuilderClock(func() time.Time { return mtime }))
+		defer cleanup()
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := fs.Lstat("/foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.ModTime().Equal(mtime) {
+			t.Errorf("info.ModTime() == %v, want %v", info.ModTime(), mtime)
+		}
+	})
+
+	t.Run("on_conflict_overwrite", func(t *testing.T) {
+		fs, cleanup, err := newTempFS()
+		defer cleanup()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := NewBuilder(BuilderOnConflict(ConflictOverwrite))
+		if err := b.Build(fs, map[string]string{"foo": "bar"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Build(fs, map[string]string{"foo": "baz"}); err != nil {
+			t.Errorf("got %v, want <nil>", err)
+		}
+		RunTest(t, fs, "", TestPath("/foo", TestContentsString("baz")))
+	})
+
+	t.Run("on_conflict_skip", func(t *testing.T) {
+		fs, cleanup, err := newTempFS()
+		defer cleanup()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := NewBuilder(BuilderOnConflict(ConflictSkip))
+		if err := b.Build(fs, map[string]string{"foo": "bar"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Build(fs, map[string]string{"foo": "baz"}); err != nil {
+			t.Errorf("got %v, want <nil>", err)
+		}
+		RunTest(t, fs, "", TestPath("/foo", TestContentsString("bar")))
+	})
+
+	t.Run("logger", func(t *testing.T) {
+		logger := log.New(testWriter{t}, "", 0)
+		fs, cleanup, err := NewTempFS(map[string]interface{}{
+			"foo": "bar",
+		}, BuilderLogger(logger))
+		defer cleanup()
+		if err != nil {
+			t.Fatal(err)
+		}
+		RunTest(t, fs, "", TestPath("/foo", TestContentsString("bar")))
+	})
+}
+
+type testWriter struct {
+	t *testing.T
+}
+
+func (w testWriter) Write(p []byte) (int, error) {
+	w.t.Log(string(p))
+	return len(p), nil
+}
+
 // TestCoverage exercises as much functionality as possible to increase test
 // coverage.
 func TestCoverage(t *testing.T) {
@@ -173,6 +275,9 @@ func TestErrors(t *testing.T) {
 		"mkdir_all_via_existing_file": func(b *Builder, fs vfs.FS) error {
 			return b.MkdirAll(fs, "/home/user/empty/foo", 0755)
 		},
+		"symlink_with_different_target": func(b *Builder, fs vfs.FS) error {
+			return b.Symlink(fs, "/elsewhere", "/home/user/link")
+		},
 	} {
 		t.Run(name, func(t *testing.T) {
 			fs, cleanup, err := newTempFS()
@@ -185,6 +290,7 @@ func TestErrors(t *testing.T) {
 				"/home/user/.bashrc": "# bashrc\n",
 				"/home/user/empty":   []byte{},
 				"/home/user/foo":     &Dir{Perm: 0755},
+				"/home/user/link":    &Symlink{Target: "/home/user/.bashrc"},
 			}
 			if err := b.Build(fs, root); err != nil {
 				t.Fatalf("b.Build(fs, root) == %v, want <nil>", err)
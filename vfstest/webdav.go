@@ -0,0 +1,248 @@
+package vfstest
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/webdav"
+
+	"github.com/twpayne/go-vfs"
+	"github.com/twpayne/go-vfs/vfswebdav"
+)
+
+// RunWebDAVConformance exercises fs through the webdav.FileSystem adapter
+// returned by vfswebdav.Handler, covering the OpenFile flag combinations,
+// Seek, and Readdir semantics that webdav.Handler relies on, a scripted
+// mkdir/touch/copy/move mini-language modeled on golang.org/x/net/webdav's
+// own file_test.go, and a TestDirResolve-style matrix of differently
+// formatted but equivalent paths.
+func RunWebDAVConformance(t *testing.T, fs vfs.FS) {
+	ctx := context.Background()
+	wfs := vfswebdav.Handler(fs)
+
+	t.Run("mkdir_and_stat", func(t *testing.T) {
+		if err := wfs.Mkdir(ctx, "/webdav-dir", 0755); err != nil {
+			t.Fatal(err)
+		}
+		info, err := wfs.Stat(ctx, "/webdav-dir")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !info.IsDir() {
+			t.Error("Stat(/webdav-dir).IsDir() == false, want true")
+		}
+	})
+
+	t.Run("create_write_seek_read", func(t *testing.T) {
+		f, err := wfs.OpenFile(ctx, "/webdav-dir/foo", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte("hello world")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			t.Fatal(err)
+		}
+		contents, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if string(contents) != "hello world" {
+			t.Errorf("contents == %q, want %q", contents, "hello world")
+		}
+	})
+
+	t.Run("open_nonexistent_without_create", func(t *testing.T) {
+		if _, err := wfs.OpenFile(ctx, "/webdav-dir/notexist", os.O_RDONLY, 0); !os.IsNotExist(err) {
+			t.Errorf("err == %v, want IsNotExist", err)
+		}
+	})
+
+	t.Run("readdir", func(t *testing.T) {
+		f, err := wfs.OpenFile(ctx, "/webdav-dir", os.O_RDONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		infos, err := f.Readdir(-1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, info := range infos {
+			if info.Name() == "foo" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Readdir(/webdav-dir) did not contain %q", "foo")
+		}
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		if err := wfs.Rename(ctx, "/webdav-dir/foo", "/webdav-dir/bar"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wfs.Stat(ctx, "/webdav-dir/bar"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wfs.Stat(ctx, "/webdav-dir/foo"); !os.IsNotExist(err) {
+			t.Errorf("Stat(/webdav-dir/foo) err == %v, want IsNotExist", err)
+		}
+	})
+
+	t.Run("remove_all", func(t *testing.T) {
+		if err := wfs.RemoveAll(ctx, "/webdav-dir"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wfs.Stat(ctx, "/webdav-dir"); !os.IsNotExist(err) {
+			t.Errorf("Stat(/webdav-dir) err == %v, want IsNotExist", err)
+		}
+	})
+
+	t.Run("script", func(t *testing.T) {
+		runWebDAVScript(t, ctx, wfs, []string{
+			"mkdir /script",
+			"mkdir /script/sub",
+			"touch /script/sub/a",
+			"write /script/sub/a hello",
+			"copy__ /script/sub/a /script/sub/b",
+			"move__ /script/sub/b /script/c",
+		})
+		if contents := webdavReadFile(t, ctx, wfs, "/script/sub/a"); contents != "hello" {
+			t.Errorf("contents of /script/sub/a == %q, want %q", contents, "hello")
+		}
+		if contents := webdavReadFile(t, ctx, wfs, "/script/c"); contents != "hello" {
+			t.Errorf("contents of /script/c == %q, want %q", contents, "hello")
+		}
+		if _, err := wfs.Stat(ctx, "/script/sub/b"); !os.IsNotExist(err) {
+			t.Errorf("Stat(/script/sub/b) err == %v, want IsNotExist", err)
+		}
+	})
+
+	t.Run("path_resolution", func(t *testing.T) {
+		if err := wfs.Mkdir(ctx, "/resolve", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := wfs.Mkdir(ctx, "/resolve/dir", 0755); err != nil {
+			t.Fatal(err)
+		}
+		webdavWriteFile(t, ctx, wfs, "/resolve/dir/file", "contents")
+
+		for _, tc := range []struct {
+			name string
+			path string
+		}{
+			{name: "clean", path: "/resolve/dir/file"},
+			{name: "double_slash", path: "/resolve//dir//file"},
+			{name: "inner_dot", path: "/resolve/dir/./file"},
+			{name: "dot_dot", path: "/resolve/other/../dir/file"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				info, err := wfs.Stat(ctx, tc.path)
+				if err != nil {
+					t.Fatalf("Stat(%q) == _, %v, want _, <nil>", tc.path, err)
+				}
+				if info.Name() != "file" {
+					t.Errorf("Stat(%q).Name() == %q, want %q", tc.path, info.Name(), "file")
+				}
+				if contents := webdavReadFile(t, ctx, wfs, tc.path); contents != "contents" {
+					t.Errorf("contents of %q == %q, want %q", tc.path, contents, "contents")
+				}
+			})
+		}
+
+		for _, tc := range []struct {
+			name string
+			path string
+		}{
+			{name: "clean", path: "/resolve/dir"},
+			{name: "trailing_slash", path: "/resolve/dir/"},
+			{name: "double_trailing_slash", path: "/resolve/dir//"},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				info, err := wfs.Stat(ctx, tc.path)
+				if err != nil {
+					t.Fatalf("Stat(%q) == _, %v, want _, <nil>", tc.path, err)
+				}
+				if !info.IsDir() {
+					t.Errorf("Stat(%q).IsDir() == false, want true", tc.path)
+				}
+			})
+		}
+	})
+}
+
+// runWebDAVScript runs a sequence of mkdir/touch/write/copy__/move__
+// commands against wfs, modeled on the mini-language used by
+// golang.org/x/net/webdav's own file_test.go to build a directory tree in
+// a single readable script. Each command is a single space-separated line;
+// copy__ and move__ keep the trailing underscores of that mini-language.
+func runWebDAVScript(t *testing.T, ctx context.Context, wfs webdav.FileSystem, script []string) {
+	t.Helper()
+	for _, line := range script {
+		fields := strings.Fields(line)
+		verb, args := fields[0], fields[1:]
+		switch verb {
+		case "mkdir":
+			if err := wfs.Mkdir(ctx, args[0], 0755); err != nil {
+				t.Fatalf("%q: %v", line, err)
+			}
+		case "touch":
+			f, err := wfs.OpenFile(ctx, args[0], os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+			if err != nil {
+				t.Fatalf("%q: %v", line, err)
+			}
+			if err := f.Close(); err != nil {
+				t.Fatalf("%q: %v", line, err)
+			}
+		case "write":
+			webdavWriteFile(t, ctx, wfs, args[0], args[1])
+		case "copy__":
+			contents := webdavReadFile(t, ctx, wfs, args[0])
+			webdavWriteFile(t, ctx, wfs, args[1], contents)
+		case "move__":
+			if err := wfs.Rename(ctx, args[0], args[1]); err != nil {
+				t.Fatalf("%q: %v", line, err)
+			}
+		default:
+			t.Fatalf("%q: unknown verb %q", line, verb)
+		}
+	}
+}
+
+func webdavWriteFile(t *testing.T, ctx context.Context, wfs webdav.FileSystem, name, contents string) {
+	t.Helper()
+	f, err := wfs.OpenFile(ctx, name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		t.Fatalf("OpenFile(%q) == _, %v, want _, <nil>", name, err)
+	}
+	if _, err := f.Write([]byte(contents)); err != nil {
+		t.Fatalf("Write(%q) == _, %v, want _, <nil>", name, err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close(%q) == %v, want <nil>", name, err)
+	}
+}
+
+func webdavReadFile(t *testing.T, ctx context.Context, wfs webdav.FileSystem, name string) string {
+	t.Helper()
+	f, err := wfs.OpenFile(ctx, name, os.O_RDONLY, 0)
+	if err != nil {
+		t.Fatalf("OpenFile(%q) == _, %v, want _, <nil>", name, err)
+	}
+	defer f.Close()
+	contents, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatalf("ReadAll(%q) == _, %v, want _, <nil>", name, err)
+	}
+	return string(contents)
+}
@@ -0,0 +1,45 @@
+// Package vfswebdav adapts a vfs.FS to golang.org/x/net/webdav.FileSystem,
+// so that any vfs.FS (including a temporary test filesystem built by
+// vfstest) can be served over WebDAV.
+package vfswebdav
+
+import (
+	"context"
+	"os"
+
+	"golang.org/x/net/webdav"
+
+	"github.com/twpayne/go-vfs"
+)
+
+// Handler returns fs as a webdav.FileSystem.
+func Handler(fs vfs.FS) webdav.FileSystem {
+	return handler{fs: fs}
+}
+
+type handler struct {
+	fs vfs.FS
+}
+
+func (h handler) Mkdir(_ context.Context, name string, perm os.FileMode) error {
+	return h.fs.Mkdir(name, perm)
+}
+
+// OpenFile returns fs's *os.File for name, which satisfies webdav.File
+// directly since it already implements io.Reader, io.Writer, io.Seeker,
+// Readdir, and Stat.
+func (h handler) OpenFile(_ context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	return h.fs.OpenFile(name, flag, perm)
+}
+
+func (h handler) RemoveAll(_ context.Context, name string) error {
+	return h.fs.RemoveAll(name)
+}
+
+func (h handler) Rename(_ context.Context, oldName, newName string) error {
+	return h.fs.Rename(oldName, newName)
+}
+
+func (h handler) Stat(_ context.Context, name string) (os.FileInfo, error) {
+	return h.fs.Stat(name)
+}
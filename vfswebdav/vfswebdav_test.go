@@ -0,0 +1,16 @@
+package vfswebdav_test
+
+import (
+	"testing"
+
+	"github.com/twpayne/go-vfs/vfstest"
+)
+
+func TestHandler(t *testing.T) {
+	fs, cleanup, err := vfstest.NewTempFS(nil)
+	defer cleanup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vfstest.RunWebDAVConformance(t, fs)
+}